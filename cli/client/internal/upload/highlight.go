@@ -0,0 +1,155 @@
+package upload
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// HighlightOptions controls how Highlight renders content.
+type HighlightOptions struct {
+	Theme          string   // chroma style name (e.g. "github", "monokai"); defaults to "github"
+	LineNumbers    bool     // render a line-number gutter
+	HighlightLines [][2]int // inclusive 1-based [start, end] line ranges to emphasize
+	Standalone     bool     // render a complete HTML document instead of a class-annotated fragment
+}
+
+// lexerAliases translates FileType.Syntax identifiers that don't match a
+// chroma lexer name directly.
+var lexerAliases = map[string]string{
+	"objective-c": "objc",
+	"csharp":      "c#",
+	"fsharp":      "fsharp",
+	"pgsql":       "postgresql",
+	"shell":       "bash",
+	"dockerfile":  "docker",
+}
+
+// Highlight renders content as syntax-highlighted HTML using the lexer for
+// ft.Syntax, returning the HTML markup and (unless opts.Standalone is set)
+// the CSS needed to style it. ft may be nil or carry Syntax "auto", in
+// which case chroma's content-based analyzer picks the lexer.
+func Highlight(content []byte, ft *FileType, opts HighlightOptions) (htmlOut string, css string, err error) {
+	lexer := chroma.Coalesce(lexerFor(ft, content))
+
+	iterator, err := lexer.Tokenise(nil, string(content))
+	if err != nil {
+		return "", "", fmt.Errorf("upload: tokenize content: %w", err)
+	}
+
+	theme := opts.Theme
+	if theme == "" {
+		theme = "github"
+	}
+	style := styles.Get(theme)
+	if style == nil {
+		style = styles.Fallback
+	}
+
+	formatter := chromaHTMLFormatter(opts)
+
+	var htmlBuf strings.Builder
+	if err := formatter.Format(&htmlBuf, style, iterator); err != nil {
+		return "", "", fmt.Errorf("upload: format HTML: %w", err)
+	}
+
+	var cssBuf strings.Builder
+	if !opts.Standalone {
+		if err := formatter.WriteCSS(&cssBuf, style); err != nil {
+			return "", "", fmt.Errorf("upload: write CSS: %w", err)
+		}
+	}
+
+	return htmlBuf.String(), cssBuf.String(), nil
+}
+
+// chromaHTMLFormatter builds the chroma HTML formatter for opts.
+func chromaHTMLFormatter(opts HighlightOptions) *html.Formatter {
+	formatterOpts := []html.Option{html.WithClasses(true)}
+	if opts.LineNumbers {
+		formatterOpts = append(formatterOpts, html.WithLineNumbers(true))
+	}
+	if len(opts.HighlightLines) > 0 {
+		formatterOpts = append(formatterOpts, html.HighlightLines(opts.HighlightLines))
+	}
+	if opts.Standalone {
+		formatterOpts = append(formatterOpts, html.Standalone(true))
+	}
+	return html.New(formatterOpts...)
+}
+
+// lexerFor resolves the chroma lexer for ft, translating mismatched syntax
+// identifiers via lexerAliases and falling back to chroma's content-based
+// analyzer when ft is nil or its Syntax is "auto" or otherwise unrecognized.
+func lexerFor(ft *FileType, content []byte) chroma.Lexer {
+	if ft != nil && ft.Syntax != "" && ft.Syntax != "auto" {
+		name := ft.Syntax
+		if alias, ok := lexerAliases[name]; ok {
+			name = alias
+		}
+		if lexer := lexers.Get(name); lexer != nil {
+			return lexer
+		}
+	}
+
+	if lexer := lexers.Analyse(string(content)); lexer != nil {
+		return lexer
+	}
+	return lexers.Fallback
+}
+
+// highlightRequest is the JSON body accepted by HighlightHandler.
+type highlightRequest struct {
+	Filename       string   `json:"filename"`
+	Content        string   `json:"content"`
+	Theme          string   `json:"theme,omitempty"`
+	LineNumbers    bool     `json:"line_numbers,omitempty"`
+	HighlightLines [][2]int `json:"highlight_lines,omitempty"`
+	Standalone     bool     `json:"standalone,omitempty"`
+}
+
+// highlightResponse is the JSON response produced by HighlightHandler.
+type highlightResponse struct {
+	HTML string `json:"html"`
+	CSS  string `json:"css,omitempty"`
+}
+
+// HighlightHandler renders a POSTed file's content as syntax-highlighted
+// HTML, with accompanying CSS unless Standalone is requested, giving the
+// upload service a viewer-ready rendering path without pushing the work to
+// clients.
+func HighlightHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req highlightRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	content := []byte(req.Content)
+	ft := DetectLanguage(req.Filename, content)
+
+	htmlOut, css, err := Highlight(content, ft, HighlightOptions{
+		Theme:          req.Theme,
+		LineNumbers:    req.LineNumbers,
+		HighlightLines: req.HighlightLines,
+		Standalone:     req.Standalone,
+	})
+	if err != nil {
+		http.Error(w, "highlight failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(highlightResponse{HTML: htmlOut, CSS: css})
+}
@@ -0,0 +1,192 @@
+package upload
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// LanguageMatch is the result of content-based language detection, paired
+// with a confidence score so callers can decide whether to trust it.
+type LanguageMatch struct {
+	FileType   *FileType
+	Confidence float64 // 0 (no signal) to 1 (certain)
+}
+
+// filenameOverrides maps well-known basenames (lowercased, matched without
+// regard to extension) to a syntax identifier. This covers files that carry
+// no useful extension of their own.
+var filenameOverrides = map[string]string{
+	"dockerfile":      "dockerfile",
+	"makefile":        "makefile",
+	"gnumakefile":     "makefile",
+	"cmakelists.txt":  "cmake",
+	"rakefile":        "ruby",
+	"gemfile":         "ruby",
+	"gemfile.lock":    "ruby",
+	"guardfile":       "ruby",
+	"vagrantfile":     "ruby",
+	".gitignore":      "git",
+	".gitattributes":  "git",
+	".gitmodules":     "git",
+	".bashrc":         "bash",
+	".bash_profile":   "bash",
+	".zshrc":          "shell",
+	".htaccess":       "apache",
+	"extensions.conf": "ini",
+}
+
+// shebangInterpreters maps an interpreter basename (with any version suffix
+// stripped) to a syntax identifier.
+var shebangInterpreters = map[string]string{
+	"sh":      "bash",
+	"bash":    "bash",
+	"dash":    "bash",
+	"zsh":     "shell",
+	"fish":    "shell",
+	"ksh":     "shell",
+	"csh":     "shell",
+	"tcsh":    "shell",
+	"python":  "python",
+	"perl":    "perl",
+	"ruby":    "ruby",
+	"node":    "javascript",
+	"nodejs":  "javascript",
+	"php":     "php",
+	"lua":     "lua",
+	"tclsh":   "tcl",
+	"escript": "erlang",
+	"rscript": "r",
+	"wish":    "tcl",
+}
+
+// contentRule is one entry in the data-driven heuristic table used to
+// disambiguate extensions that are shared by more than one language.
+type contentRule struct {
+	Ext        string
+	Pattern    *regexp.Regexp
+	Syntax     string
+	Confidence float64
+}
+
+// contentHeuristics resolves ambiguous extensions by scanning file content
+// for telltale patterns. Rules for the same extension are evaluated in
+// order; the first match wins. New rules can be appended here without
+// touching the detection logic in DetectLanguageMatch.
+var contentHeuristics = []contentRule{
+	// .h: Objective-C vs C++ vs C (C is the extension-map fallback)
+	{Ext: "h", Pattern: regexp.MustCompile(`@interface\b|@property\b|@end\b`), Syntax: "objective-c", Confidence: 0.9},
+	{Ext: "h", Pattern: regexp.MustCompile(`\bnamespace\s+\w+|\btemplate\s*<|\bclass\s+\w+`), Syntax: "cpp", Confidence: 0.8},
+
+	// .m: Objective-C vs MATLAB
+	{Ext: "m", Pattern: regexp.MustCompile(`@implementation\b|@interface\b|#import\b`), Syntax: "objective-c", Confidence: 0.9},
+	{Ext: "m", Pattern: regexp.MustCompile(`(?m)^\s*function\b[\s\S]*?\bend\b`), Syntax: "matlab", Confidence: 0.6},
+
+	// .ts: TypeScript vs Qt's XML translation-source format
+	{Ext: "ts", Pattern: regexp.MustCompile(`^\s*<\?xml|<TS\b`), Syntax: "xml", Confidence: 0.85},
+
+	// .pl: Perl vs Prolog
+	{Ext: "pl", Pattern: regexp.MustCompile(`:-\s*(module|initialization|dynamic)\b`), Syntax: "prolog", Confidence: 0.85},
+	{Ext: "pl", Pattern: regexp.MustCompile(`\buse strict\b|\buse warnings\b|\$\w+\s*=`), Syntax: "perl", Confidence: 0.6},
+
+	// .sc: Scala vs SuperCollider
+	{Ext: "sc", Pattern: regexp.MustCompile(`\bSynthDef\b|\.play\b|\bServer\.default\b`), Syntax: "supercollider", Confidence: 0.8},
+	{Ext: "sc", Pattern: regexp.MustCompile(`\bobject\s+\w+|\bdef\s+\w+\s*\(|\bval\s+\w+\s*=`), Syntax: "scala", Confidence: 0.6},
+}
+
+// DetectLanguage returns the best-guess FileType for filename and content,
+// layering filename overrides, registered filename patterns, shebang
+// parsing, content heuristics, and finally the extension map. Use
+// DetectLanguageMatch if the confidence score is needed.
+func DetectLanguage(filename string, content []byte) *FileType {
+	return DetectLanguageMatch(filename, content).FileType
+}
+
+// DetectLanguageMatch is DetectLanguage with the confidence of the match
+// that was used to resolve it.
+func DetectLanguageMatch(filename string, content []byte) LanguageMatch {
+	base := strings.ToLower(filepath.Base(filename))
+
+	// 1. Filename overrides for extensionless well-known files.
+	if syntax, ok := filenameOverrides[base]; ok {
+		if ft := GetFileTypeBySyntax(syntax); ft != nil {
+			return LanguageMatch{FileType: ft, Confidence: 1}
+		}
+	}
+
+	// 2. Registered filename patterns, for FileTypes registered (via
+	// Register or LoadFromYAML/LoadFromJSON) with a FilenamePattern instead
+	// of, or in addition to, an extension list.
+	if ft := defaultRegistry.LookupFilename(filename); ft != &unknownType {
+		return LanguageMatch{FileType: ft, Confidence: 1}
+	}
+
+	// 3. Shebang parsing.
+	if ft, ok := detectShebang(content); ok {
+		return LanguageMatch{FileType: ft, Confidence: 0.95}
+	}
+
+	// 4. Content heuristics for ambiguous extensions.
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	if ext != "" && len(content) > 0 {
+		for _, rule := range contentHeuristics {
+			if rule.Ext != ext {
+				continue
+			}
+			if rule.Pattern.Match(content) {
+				if ft := GetFileTypeBySyntax(rule.Syntax); ft != nil {
+					return LanguageMatch{FileType: ft, Confidence: rule.Confidence}
+				}
+			}
+		}
+	}
+
+	// 5. Fallback to the extension map.
+	ft := GetFileTypeByExtension(ext)
+	if ft == &unknownType {
+		return LanguageMatch{FileType: ft, Confidence: 0}
+	}
+	return LanguageMatch{FileType: ft, Confidence: 0.5}
+}
+
+// detectShebang parses the first line of content for a "#!" interpreter
+// directive and maps the interpreter to a FileType, following "env" to the
+// program it invokes (e.g. "#!/usr/bin/env python3").
+func detectShebang(content []byte) (*FileType, bool) {
+	line := firstLine(content)
+	if !strings.HasPrefix(line, "#!") {
+		return nil, false
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return nil, false
+	}
+
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" && len(fields) > 1 {
+		interpreter = filepath.Base(fields[1])
+	}
+	interpreter = strings.ToLower(stripVersionSuffix(interpreter))
+
+	syntax, ok := shebangInterpreters[interpreter]
+	if !ok {
+		return nil, false
+	}
+	return GetFileTypeBySyntax(syntax), true
+}
+
+// firstLine returns content up to (but not including) the first newline.
+func firstLine(content []byte) string {
+	if idx := bytes.IndexByte(content, '\n'); idx >= 0 {
+		content = content[:idx]
+	}
+	return string(bytes.TrimRight(content, "\r"))
+}
+
+// stripVersionSuffix trims trailing version digits and dots from an
+// interpreter name, e.g. "python3.11" -> "python", "perl5" -> "perl".
+func stripVersionSuffix(name string) string {
+	return strings.TrimRight(name, "0123456789.")
+}
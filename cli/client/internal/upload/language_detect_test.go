@@ -0,0 +1,119 @@
+package upload
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestDetectLanguageFilenameOverrides(t *testing.T) {
+	cases := []struct {
+		filename string
+		syntax   string
+	}{
+		{"Dockerfile", "dockerfile"},
+		{"Makefile", "makefile"},
+		{"CMakeLists.txt", "cmake"},
+		{"Rakefile", "ruby"},
+		{"Gemfile", "ruby"},
+		{".gitignore", "git"},
+		{".bashrc", "bash"},
+		{".zshrc", "shell"},
+		{".htaccess", "apache"},
+		{"/project/extensions.conf", "ini"},
+	}
+	for _, c := range cases {
+		match := DetectLanguageMatch(c.filename, nil)
+		if match.FileType.Syntax != c.syntax {
+			t.Errorf("DetectLanguageMatch(%q) = %q, want %q", c.filename, match.FileType.Syntax, c.syntax)
+		}
+		if match.Confidence != 1 {
+			t.Errorf("DetectLanguageMatch(%q) confidence = %v, want 1", c.filename, match.Confidence)
+		}
+	}
+}
+
+func TestDetectLanguageRegisteredFilenamePattern(t *testing.T) {
+	defaultRegistry.Register(FileType{
+		Syntax:          "procfile",
+		Category:        "code",
+		FilenamePattern: regexp.MustCompile(`(?i)^Procfile$`),
+	})
+	defer defaultRegistry.Unregister("procfile")
+
+	match := DetectLanguageMatch("Procfile", nil)
+	if match.FileType.Syntax != "procfile" {
+		t.Errorf("DetectLanguageMatch(%q) = %q, want procfile", "Procfile", match.FileType.Syntax)
+	}
+	if match.Confidence != 1 {
+		t.Errorf("DetectLanguageMatch(%q) confidence = %v, want 1", "Procfile", match.Confidence)
+	}
+}
+
+func TestDetectLanguageShebang(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		syntax  string
+	}{
+		{"plain bash", "#!/bin/bash\necho hi\n", "bash"},
+		{"env python3", "#!/usr/bin/env python3\nprint('hi')\n", "python"},
+		{"env node", "#!/usr/bin/env node\nconsole.log('hi')\n", "javascript"},
+		{"perl", "#!/usr/bin/perl\nuse strict;\n", "perl"},
+	}
+	for _, c := range cases {
+		match := DetectLanguageMatch("script", []byte(c.content))
+		if match.FileType.Syntax != c.syntax {
+			t.Errorf("%s: DetectLanguageMatch(...) = %q, want %q", c.name, match.FileType.Syntax, c.syntax)
+		}
+		if match.Confidence != 0.95 {
+			t.Errorf("%s: confidence = %v, want 0.95", c.name, match.Confidence)
+		}
+	}
+}
+
+func TestDetectLanguageContentHeuristics(t *testing.T) {
+	cases := []struct {
+		name     string
+		filename string
+		content  string
+		syntax   string
+	}{
+		{"objc header", "Foo.h", "@interface Foo : NSObject\n@property int x;\n@end\n", "objective-c"},
+		{"cpp header", "foo.h", "namespace foo {\ntemplate <typename T> class Bar {};\n}\n", "cpp"},
+		{"plain c header", "foo.h", "int add(int a, int b);\n", "c"},
+		{"objc source", "Foo.m", "#import <Foundation/Foundation.h>\n@implementation Foo\n@end\n", "objective-c"},
+		{"matlab source", "foo.m", "function y = square(x)\n  y = x^2;\nend\n", "matlab"},
+		{"ts qt linguist", "app.ts", "<?xml version=\"1.0\"?>\n<TS version=\"2.1\">\n</TS>\n", "xml"},
+		{"typescript", "app.ts", "interface Foo { x: number }\n", "typescript"},
+		{"prolog", "rules.pl", ":- module(rules, [foo/1]).\n", "prolog"},
+		{"perl", "script.pl", "use strict;\nuse warnings;\nmy $x = 1;\n", "perl"},
+		{"supercollider", "patch.sc", "SynthDef(\\sine, { Out.ar(0, SinOsc.ar) }).play;\n", "supercollider"},
+		{"scala", "App.sc", "object App {\n  def main(args: Array[String]) = {\n    val x = 1\n  }\n}\n", "scala"},
+	}
+	for _, c := range cases {
+		match := DetectLanguageMatch(c.filename, []byte(c.content))
+		if match.FileType.Syntax != c.syntax {
+			t.Errorf("%s: DetectLanguageMatch(%q, ...) = %q, want %q", c.name, c.filename, match.FileType.Syntax, c.syntax)
+		}
+	}
+}
+
+func TestDetectLanguageExtensionFallback(t *testing.T) {
+	match := DetectLanguageMatch("main.go", []byte("package main\n"))
+	if match.FileType.Syntax != "go" {
+		t.Errorf("FileType.Syntax = %q, want %q", match.FileType.Syntax, "go")
+	}
+	if match.Confidence != 0.5 {
+		t.Errorf("Confidence = %v, want 0.5", match.Confidence)
+	}
+}
+
+func TestDetectLanguageUnknown(t *testing.T) {
+	match := DetectLanguageMatch("mystery.xyzzy", []byte("whatever"))
+	if match.FileType.Syntax != "auto" {
+		t.Errorf("FileType.Syntax = %q, want %q", match.FileType.Syntax, "auto")
+	}
+	if match.Confidence != 0 {
+		t.Errorf("Confidence = %v, want 0", match.Confidence)
+	}
+}
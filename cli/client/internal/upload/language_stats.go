@@ -0,0 +1,129 @@
+package upload
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// FileEntry describes one file contributing to a language composition
+// report. Content is optional: when present it is used for the
+// shebang/heuristic stages of DetectLanguageMatch; when absent, detection
+// falls back to filename-only matching.
+type FileEntry struct {
+	Filename string
+	Size     int64
+	Content  []byte
+}
+
+// LanguageStats is a GitHub-style language composition report: byte counts
+// per language and per category, plus the single dominant language.
+type LanguageStats struct {
+	ByLanguage map[string]int64 `json:"by_language"` // syntax identifier -> bytes
+	ByCategory map[string]int64 `json:"by_category"` // category -> bytes
+	Total      int64            `json:"total"`
+	Dominant   string           `json:"dominant"`
+}
+
+// excludedDirs are path segments that mark a file as vendored/generated
+// regardless of how deep they appear in the path.
+var excludedDirs = []string{"vendor", "node_modules", "dist"}
+
+// excludedGlobs are basename patterns for generated/minified output.
+var excludedGlobs = []string{"*.min.js", "*.min.css"}
+
+// isExcludedPath reports whether filename should be excluded from language
+// composition by default: anywhere under a vendored/generated directory, or
+// matching a minified-output glob.
+func isExcludedPath(filename string) bool {
+	filename = strings.TrimPrefix(filename, "/")
+
+	for _, segment := range strings.Split(filename, "/") {
+		for _, dir := range excludedDirs {
+			if segment == dir {
+				return true
+			}
+		}
+	}
+
+	base := path.Base(filename)
+	for _, pattern := range excludedGlobs {
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ComputeLanguageStats classifies each file via DetectLanguageMatch and
+// weights the result by byte count, producing a report suitable for
+// rendering a GitHub-style stacked language bar. Files that isExcludedPath
+// reports as vendored or generated (vendor/, node_modules/, dist/, *.min.js,
+// ...) are skipped.
+func ComputeLanguageStats(files []FileEntry) LanguageStats {
+	stats := LanguageStats{
+		ByLanguage: make(map[string]int64),
+		ByCategory: make(map[string]int64),
+	}
+
+	for _, f := range files {
+		if isExcludedPath(f.Filename) {
+			continue
+		}
+
+		ft := DetectLanguage(f.Filename, f.Content)
+		if ft == nil || ft.Category == "unknown" {
+			continue
+		}
+
+		stats.ByLanguage[ft.Syntax] += f.Size
+		stats.ByCategory[ft.Category] += f.Size
+		stats.Total += f.Size
+	}
+
+	var dominant string
+	var dominantBytes int64
+	for syntax, bytes := range stats.ByLanguage {
+		if bytes > dominantBytes {
+			dominant, dominantBytes = syntax, bytes
+		}
+	}
+	stats.Dominant = dominant
+
+	return stats
+}
+
+// languageStatsRequest is the JSON body accepted by LanguageStatsHandler.
+type languageStatsRequest struct {
+	Files []struct {
+		Filename string `json:"filename"`
+		Size     int64  `json:"size"`
+		Content  string `json:"content,omitempty"`
+	} `json:"files"`
+}
+
+// LanguageStatsHandler computes a LanguageStats report for a POSTed list of
+// files and returns it as JSON, ready for rendering a stacked language bar.
+func LanguageStatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req languageStatsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	files := make([]FileEntry, len(req.Files))
+	for i, f := range req.Files {
+		files[i] = FileEntry{Filename: f.Filename, Size: f.Size, Content: []byte(f.Content)}
+	}
+
+	stats := ComputeLanguageStats(files)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
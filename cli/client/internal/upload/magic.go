@@ -0,0 +1,93 @@
+package upload
+
+import "bytes"
+
+// magicSignature pairs a byte-string signature with the syntax identifier it
+// implies when found at the start of a file's content. Weak signatures are
+// short enough (e.g. two bytes) that they occur incidentally in ordinary
+// text, so they are only trusted as a last-resort tiebreaker rather than
+// being allowed to override a recognized extension; see sniffMagic.
+type magicSignature struct {
+	Magic  []byte
+	Syntax string
+	Weak   bool
+}
+
+// magicSignatures covers the archive/binary types already listed in the
+// registry. Entries are tried in order; the first prefix match wins.
+var magicSignatures = []magicSignature{
+	{Magic: []byte("PK\x03\x04"), Syntax: "archive"},                     // ZIP
+	{Magic: []byte("PK\x05\x06"), Syntax: "archive"},                     // ZIP, empty archive
+	{Magic: []byte{0x1f, 0x8b}, Syntax: "archive"},                       // gzip
+	{Magic: []byte("BZh"), Syntax: "archive"},                            // bzip2
+	{Magic: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, Syntax: "archive"},   // xz
+	{Magic: []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}, Syntax: "archive"}, // 7z
+	{Magic: []byte("Rar!\x1a\x07"), Syntax: "archive"},                   // RAR
+	{Magic: []byte("%PDF-"), Syntax: "pdf"},
+	{Magic: []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}, Syntax: "image"}, // PNG
+	{Magic: []byte{0xff, 0xd8, 0xff}, Syntax: "image"},                            // JPEG
+	{Magic: []byte("GIF87a"), Syntax: "image"},
+	{Magic: []byte("GIF89a"), Syntax: "image"},
+	{Magic: []byte("BM"), Syntax: "image", Weak: true},       // BMP
+	{Magic: []byte{'I', 'I', 0x2a, 0x00}, Syntax: "image"},   // TIFF, little-endian
+	{Magic: []byte{'M', 'M', 0x00, 0x2a}, Syntax: "image"},   // TIFF, big-endian
+	{Magic: []byte{0x00, 0x00, 0x01, 0x00}, Syntax: "image"}, // ICO
+	{Magic: []byte{0x00, 'a', 's', 'm'}, Syntax: "wasm"},
+	{Magic: []byte{0xca, 0xfe, 0xba, 0xbe}, Syntax: "java"},   // Java class
+	{Magic: []byte{0x7f, 'E', 'L', 'F'}, Syntax: "binary"},    // ELF
+	{Magic: []byte{0xfe, 0xed, 0xfa, 0xce}, Syntax: "binary"}, // Mach-O 32-bit, big-endian
+	{Magic: []byte{0xce, 0xfa, 0xed, 0xfe}, Syntax: "binary"}, // Mach-O 32-bit, little-endian
+	{Magic: []byte{0xfe, 0xed, 0xfa, 0xcf}, Syntax: "binary"}, // Mach-O 64-bit, big-endian
+	{Magic: []byte{0xcf, 0xfa, 0xed, 0xfe}, Syntax: "binary"}, // Mach-O 64-bit, little-endian
+	{Magic: []byte("MZ"), Syntax: "binary", Weak: true},       // PE/DOS executable
+}
+
+// isWebP reports whether content is a WebP image: a RIFF container
+// ("RIFF" + 4-byte size) whose form type is "WEBP".
+func isWebP(content []byte) bool {
+	return len(content) >= 12 && bytes.HasPrefix(content, []byte("RIFF")) && bytes.Equal(content[8:12], []byte("WEBP"))
+}
+
+// sniffMagic returns the syntax identifier implied by content's magic
+// number, or "" if no signature matches. Weak signatures (see
+// magicSignature.Weak) are only considered when includeWeak is true.
+func sniffMagic(content []byte, includeWeak bool) string {
+	if isWebP(content) {
+		return "image"
+	}
+	for _, sig := range magicSignatures {
+		if sig.Weak && !includeWeak {
+			continue
+		}
+		if bytes.HasPrefix(content, sig.Magic) {
+			return sig.Syntax
+		}
+	}
+	return ""
+}
+
+// SniffMagic returns the FileType implied by content's magic number, or nil
+// if no signature matches (including when content is too short to carry
+// one). Only strong, unambiguous signatures are considered; use
+// SniffMagicWeak to also consider short signatures like "BM"/"MZ" that
+// occur incidentally in ordinary text and should only break a tie, not
+// override a recognized extension.
+func SniffMagic(content []byte) *FileType {
+	syntax := sniffMagic(content, false)
+	if syntax == "" {
+		return nil
+	}
+	return GetFileTypeBySyntax(syntax)
+}
+
+// SniffMagicWeak is SniffMagic but also matches weak, short signatures. Call
+// it only as a last-resort tiebreaker after extension-based detection has
+// failed to resolve a type, since a weak signature (e.g. "BM" or "MZ")
+// matches incidentally in plenty of ordinary text.
+func SniffMagicWeak(content []byte) *FileType {
+	syntax := sniffMagic(content, true)
+	if syntax == "" {
+		return nil
+	}
+	return GetFileTypeBySyntax(syntax)
+}
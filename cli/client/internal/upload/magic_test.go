@@ -0,0 +1,140 @@
+package upload
+
+import "testing"
+
+func TestSniffMagicSignatures(t *testing.T) {
+	cases := []struct {
+		name    string
+		content []byte
+		syntax  string
+	}{
+		{"ZIP", []byte("PK\x03\x04rest"), "archive"},
+		{"ZIP empty", []byte("PK\x05\x06rest"), "archive"},
+		{"gzip", []byte{0x1f, 0x8b, 0x08}, "archive"},
+		{"bzip2", []byte("BZhrest"), "archive"},
+		{"xz", []byte{0xfd, '7', 'z', 'X', 'Z', 0x00, 0x00}, "archive"},
+		{"7z", []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c, 0x00}, "archive"},
+		{"RAR", []byte("Rar!\x1a\x07rest"), "archive"},
+		{"PDF", []byte("%PDF-1.4"), "pdf"},
+		{"PNG", []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}, "image"},
+		{"JPEG", []byte{0xff, 0xd8, 0xff, 0xe0}, "image"},
+		{"GIF87a", []byte("GIF87a;"), "image"},
+		{"GIF89a", []byte("GIF89a;"), "image"},
+		{"TIFF little-endian", []byte{'I', 'I', 0x2a, 0x00}, "image"},
+		{"TIFF big-endian", []byte{'M', 'M', 0x00, 0x2a}, "image"},
+		{"ICO", []byte{0x00, 0x00, 0x01, 0x00, 0x01}, "image"},
+		{"WebP", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), "image"},
+		{"WASM", []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}, "wasm"},
+		{"Java class", []byte{0xca, 0xfe, 0xba, 0xbe, 0x00}, "java"},
+		{"ELF", []byte{0x7f, 'E', 'L', 'F', 0x02}, "binary"},
+		{"Mach-O 32 big-endian", []byte{0xfe, 0xed, 0xfa, 0xce}, "binary"},
+		{"Mach-O 32 little-endian", []byte{0xce, 0xfa, 0xed, 0xfe}, "binary"},
+		{"Mach-O 64 big-endian", []byte{0xfe, 0xed, 0xfa, 0xcf}, "binary"},
+		{"Mach-O 64 little-endian", []byte{0xcf, 0xfa, 0xed, 0xfe}, "binary"},
+	}
+	for _, c := range cases {
+		ft := SniffMagic(c.content)
+		if ft == nil {
+			t.Errorf("%s: SniffMagic(...) = nil, want syntax %q", c.name, c.syntax)
+			continue
+		}
+		if ft.Syntax != c.syntax {
+			t.Errorf("%s: SniffMagic(...) = %q, want %q", c.name, ft.Syntax, c.syntax)
+		}
+	}
+}
+
+func TestSniffMagicNoMatch(t *testing.T) {
+	cases := [][]byte{
+		nil,
+		{},
+		[]byte("just plain text"),
+		[]byte("BM25 ranking notes for search relevance"),
+		[]byte("MZ is the rapper's stage name, not an executable"),
+	}
+	for _, content := range cases {
+		if ft := SniffMagic(content); ft != nil {
+			t.Errorf("SniffMagic(%q) = %q, want nil (strong signatures only)", content, ft.Syntax)
+		}
+	}
+}
+
+func TestSniffMagicWeakTiebreaker(t *testing.T) {
+	cases := []struct {
+		name    string
+		content []byte
+		syntax  string
+	}{
+		{"BMP", []byte("BM\x00\x00\x00\x00"), "image"},
+		{"PE/DOS", []byte("MZ\x90\x00"), "binary"},
+	}
+	for _, c := range cases {
+		if ft := SniffMagic(c.content); ft != nil {
+			t.Errorf("%s: SniffMagic(...) = %q, want nil (weak signature, not strong)", c.name, ft.Syntax)
+		}
+		ft := SniffMagicWeak(c.content)
+		if ft == nil {
+			t.Fatalf("%s: SniffMagicWeak(...) = nil, want syntax %q", c.name, c.syntax)
+		}
+		if ft.Syntax != c.syntax {
+			t.Errorf("%s: SniffMagicWeak(...) = %q, want %q", c.name, ft.Syntax, c.syntax)
+		}
+	}
+
+	if ft := SniffMagicWeak([]byte("just plain text")); ft != nil {
+		t.Errorf("SniffMagicWeak(plain text) = %q, want nil", ft.Syntax)
+	}
+}
+
+func TestDetectContentTypeWeakSignatureDoesNotOverrideExtension(t *testing.T) {
+	cases := []struct {
+		name     string
+		filename string
+		content  string
+		wantMIME string
+	}{
+		{"txt mentioning BM", "notes.txt", "BM25 ranking notes for search relevance", "text/plain"},
+		{"md mentioning MZ", "notes.md", "MZ is the rapper's stage name, not an executable", "text/markdown"},
+	}
+	for _, c := range cases {
+		got := DetectContentType(c.filename, []byte(c.content))
+		if got != c.wantMIME {
+			t.Errorf("%s: DetectContentType(%q, ...) = %q, want %q", c.name, c.filename, got, c.wantMIME)
+		}
+	}
+}
+
+func TestDetectContentTypeWeakSignatureBreaksTieWithoutExtension(t *testing.T) {
+	got := DetectContentType("", []byte("BM\x00\x00\x00\x00"))
+	if want := "image/"; got != want {
+		t.Errorf("DetectContentType(\"\", BMP bytes) = %q, want %q", got, want)
+	}
+}
+
+func TestDetectContentTypeStrongSignatureOverridesExtension(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+	got := DetectContentType("fake.txt", png)
+	if want := "image/"; got != want {
+		t.Errorf("DetectContentType(%q, PNG bytes) = %q, want %q", "fake.txt", got, want)
+	}
+}
+
+func TestIsBinaryType(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		content     []byte
+		want        bool
+	}{
+		{"image MIME", "image/png", nil, true},
+		{"pdf MIME", "application/pdf", nil, true},
+		{"text MIME", "text/plain", nil, false},
+		{"octet-stream sniffed as PNG", "application/octet-stream", []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}, true},
+		{"octet-stream no content", "application/octet-stream", nil, true},
+	}
+	for _, c := range cases {
+		if got := IsBinaryType(c.contentType, c.content); got != c.want {
+			t.Errorf("%s: IsBinaryType(%q, %v) = %v, want %v", c.name, c.contentType, c.content, got, c.want)
+		}
+	}
+}
@@ -4,16 +4,20 @@ import (
 	"mime"
 	"net/http"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
 // FileType represents a file type definition matching the frontend
 type FileType struct {
-	Syntax     string   // Language/syntax identifier (e.g., "python", "javascript")
-	Label      string   // Human-readable label (e.g., "Python", "JavaScript")
-	MIME       string   // MIME type (e.g., "text/x-python")
-	Extensions []string // File extensions without leading dot (e.g., "py", "pyw")
-	Category   string   // Category (e.g., "code", "text", "image")
+	Syntax          string         // Language/syntax identifier (e.g., "python", "javascript")
+	Label           string         // Human-readable label (e.g., "Python", "JavaScript")
+	MIME            string         // MIME type (e.g., "text/x-python")
+	MIMEs           []string       // Additional canonical MIME types beyond MIME, for languages with more than one (e.g. PGP, shader variants)
+	Extensions      []string       // File extensions without leading dot (e.g., "py", "pyw")
+	Category        string         // Category (e.g., "code", "text", "image")
+	Aliases         []string       // Alternate names for Syntax (e.g., "c++" for "cpp", "cs" for "csharp")
+	FilenamePattern *regexp.Regexp // Matches basename-only files without a useful extension (e.g. "CMakeLists.txt", ".htaccess")
 }
 
 // fileTypes is the comprehensive list of file types matching the frontend
@@ -25,8 +29,8 @@ var fileTypes = []FileType{
 	{Syntax: "typescript", Label: "TypeScript", MIME: "application/x-typescript", Extensions: []string{"ts", "tsx"}, Category: "code"},
 	{Syntax: "python", Label: "Python", MIME: "text/x-python", Extensions: []string{"py", "pyw", "pyx", "pyi", "pyd", "pyc"}, Category: "code"},
 	{Syntax: "java", Label: "Java", MIME: "text/x-java-source", Extensions: []string{"java", "class", "jar"}, Category: "code"},
-	{Syntax: "csharp", Label: "C#", MIME: "text/x-csharp", Extensions: []string{"cs", "csx"}, Category: "code"},
-	{Syntax: "cpp", Label: "C++", MIME: "text/x-c++", Extensions: []string{"cpp", "cc", "cxx", "c++", "hpp", "hh", "hxx", "h++"}, Category: "code"},
+	{Syntax: "csharp", Label: "C#", MIME: "text/x-csharp", Extensions: []string{"cs", "csx"}, Category: "code", Aliases: []string{"cs"}},
+	{Syntax: "cpp", Label: "C++", MIME: "text/x-c++", Extensions: []string{"cpp", "cc", "cxx", "c++", "hpp", "hh", "hxx", "h++"}, Category: "code", Aliases: []string{"c++"}},
 	{Syntax: "c", Label: "C", MIME: "text/x-c", Extensions: []string{"c", "h"}, Category: "code"},
 	{Syntax: "objective-c", Label: "Objective-C", MIME: "text/x-objectivec", Extensions: []string{"m", "mm"}, Category: "code"},
 	{Syntax: "swift", Label: "Swift", MIME: "text/x-swift", Extensions: []string{"swift"}, Category: "code"},
@@ -56,6 +60,11 @@ var fileTypes = []FileType{
 	{Syntax: "racket", Label: "Racket", MIME: "text/x-racket", Extensions: []string{"rkt", "rktl", "rktd"}, Category: "code"},
 	{Syntax: "lisp", Label: "Lisp", MIME: "text/x-lisp", Extensions: []string{"lisp", "lsp", "l", "cl", "fasl"}, Category: "code"},
 
+	// ============================================
+	// LOGIC & CONSTRAINT LANGUAGES
+	// ============================================
+	{Syntax: "prolog", Label: "Prolog", MIME: "text/x-prolog", Extensions: []string{"pro"}, Category: "code"},
+
 	// ============================================
 	// WEB DEVELOPMENT
 	// ============================================
@@ -81,6 +90,7 @@ var fileTypes = []FileType{
 	{Syntax: "tcl", Label: "Tcl", MIME: "text/x-tcl", Extensions: []string{"tcl"}, Category: "code"},
 	{Syntax: "awk", Label: "AWK", MIME: "text/x-awk", Extensions: []string{"awk"}, Category: "code"},
 	{Syntax: "sed", Label: "Sed", MIME: "text/x-sed", Extensions: []string{"sed"}, Category: "code"},
+	{Syntax: "coffeescript", Label: "CoffeeScript", MIME: "text/coffeescript", Extensions: []string{"coffee", "litcoffee"}, Category: "code", Aliases: []string{"coffee-script", "coffee"}},
 
 	// ============================================
 	// DATA & CONFIGURATION
@@ -89,7 +99,7 @@ var fileTypes = []FileType{
 	{Syntax: "yaml", Label: "YAML", MIME: "application/x-yaml", Extensions: []string{"yaml", "yml"}, Category: "code"},
 	{Syntax: "xml", Label: "XML", MIME: "application/xml", Extensions: []string{"xml", "xsl", "xslt", "xsd", "dtd"}, Category: "code"},
 	{Syntax: "toml", Label: "TOML", MIME: "application/toml", Extensions: []string{"toml"}, Category: "code"},
-	{Syntax: "ini", Label: "INI", MIME: "text/x-ini", Extensions: []string{"ini", "cfg", "conf", "config"}, Category: "code"},
+	{Syntax: "ini", Label: "INI", MIME: "text/x-ini", Extensions: []string{"ini", "cfg", "conf", "config"}, Category: "code", FilenamePattern: regexp.MustCompile(`(?i)^extensions\.conf$`)},
 	{Syntax: "properties", Label: "Properties", MIME: "text/x-properties", Extensions: []string{"properties"}, Category: "code"},
 	{Syntax: "csv", Label: "CSV", MIME: "text/csv", Extensions: []string{"csv"}, Category: "csv"},
 	{Syntax: "tsv", Label: "TSV", MIME: "text/tab-separated-values", Extensions: []string{"tsv"}, Category: "csv"},
@@ -132,7 +142,7 @@ var fileTypes = []FileType{
 	// ============================================
 	{Syntax: "dockerfile", Label: "Dockerfile", MIME: "text/x-dockerfile", Extensions: []string{"dockerfile"}, Category: "code"},
 	{Syntax: "makefile", Label: "Makefile", MIME: "text/x-makefile", Extensions: []string{"makefile", "mk", "mak"}, Category: "code"},
-	{Syntax: "cmake", Label: "CMake", MIME: "text/x-cmake", Extensions: []string{"cmake", "cmake.in"}, Category: "code"},
+	{Syntax: "cmake", Label: "CMake", MIME: "text/x-cmake", Extensions: []string{"cmake", "cmake.in"}, Category: "code", FilenamePattern: regexp.MustCompile(`(?i)^CMakeLists\.txt$`)},
 	{Syntax: "gradle", Label: "Gradle", MIME: "text/x-gradle", Extensions: []string{"gradle"}, Category: "code"},
 	{Syntax: "groovy", Label: "Groovy", MIME: "text/x-groovy", Extensions: []string{"groovy", "gvy", "gy", "gsh"}, Category: "code"},
 	{Syntax: "terraform", Label: "Terraform", MIME: "text/x-terraform", Extensions: []string{"tf", "tfvars", "hcl"}, Category: "code"},
@@ -169,8 +179,9 @@ var fileTypes = []FileType{
 	// ============================================
 	{Syntax: "gdscript", Label: "GDScript", MIME: "text/x-gdscript", Extensions: []string{"gd"}, Category: "code"},
 	{Syntax: "hlsl", Label: "HLSL", MIME: "text/x-hlsl", Extensions: []string{"hlsl", "fx", "fxh"}, Category: "code"},
-	{Syntax: "glsl", Label: "GLSL", MIME: "text/x-glsl", Extensions: []string{"glsl", "vert", "frag", "geom", "comp", "tesc", "tese"}, Category: "code"},
+	{Syntax: "glsl", Label: "GLSL", MIME: "text/x-glsl", MIMEs: []string{"x-shader/x-vertex", "x-shader/x-fragment", "x-shader/x-geometry"}, Extensions: []string{"glsl", "vert", "frag", "geom", "comp", "tesc", "tese"}, Category: "code"},
 	{Syntax: "wgsl", Label: "WGSL", MIME: "text/x-wgsl", Extensions: []string{"wgsl"}, Category: "code"},
+	{Syntax: "supercollider", Label: "SuperCollider", MIME: "text/x-supercollider", Extensions: []string{"scd"}, Category: "code"},
 
 	// ============================================
 	// HARDWARE DESCRIPTION LANGUAGES
@@ -199,6 +210,7 @@ var fileTypes = []FileType{
 	// ============================================
 	{Syntax: "diff", Label: "Diff", MIME: "text/x-diff", Extensions: []string{"diff", "patch"}, Category: "code"},
 	{Syntax: "git", Label: "Git Config", MIME: "text/x-git", Extensions: []string{"gitignore", "gitattributes", "gitmodules"}, Category: "code"},
+	{Syntax: "pgp", Label: "PGP", MIME: "application/pgp", MIMEs: []string{"application/pgp-keys", "application/pgp-signature"}, Extensions: []string{"pgp", "gpg", "sig"}, Category: "text"},
 	{Syntax: "svg", Label: "SVG", MIME: "image/svg+xml", Extensions: []string{"svg"}, Category: "image"},
 
 	// ============================================
@@ -207,6 +219,7 @@ var fileTypes = []FileType{
 	{Syntax: "pdf", Label: "PDF", MIME: "application/pdf", Extensions: []string{"pdf"}, Category: "pdf"},
 	{Syntax: "image", Label: "Image", MIME: "image/", Extensions: []string{"jpg", "jpeg", "png", "gif", "webp", "bmp", "ico", "tiff", "tif"}, Category: "image"},
 	{Syntax: "archive", Label: "Archive", MIME: "application/zip", Extensions: []string{"zip", "tar", "gz", "bz2", "xz", "7z", "rar", "tgz", "tbz2", "txz"}, Category: "archive"},
+	{Syntax: "binary", Label: "Binary", MIME: "application/octet-stream", Extensions: []string{"exe", "dll", "so", "dylib", "bin"}, Category: "binary"},
 
 	// ============================================
 	// PLAIN TEXT (FALLBACK)
@@ -223,76 +236,56 @@ var unknownType = FileType{
 	Category:   "unknown",
 }
 
-// extensionToFileType maps extensions to their FileType (built at init)
-var extensionToFileType map[string]*FileType
-
-// mimeToFileType maps MIME types to their FileType (built at init)
-var mimeToFileType map[string]*FileType
+// defaultRegistry is the package-wide Registry, seeded from fileTypes. It
+// preserves the lookup behavior this package has always had; operators that
+// need to add or override file types should Register against it (or build
+// their own Registry with NewRegistry).
+var defaultRegistry = NewRegistry()
 
 func init() {
-	extensionToFileType = make(map[string]*FileType)
-	mimeToFileType = make(map[string]*FileType)
-
-	for i := range fileTypes {
-		ft := &fileTypes[i]
-		// Map extensions
-		for _, ext := range ft.Extensions {
-			extensionToFileType[strings.ToLower(ext)] = ft
-		}
-		// Map MIME type
-		mimeToFileType[strings.ToLower(ft.MIME)] = ft
+	for _, ft := range fileTypes {
+		defaultRegistry.Register(ft)
 	}
 }
 
+// GetFileTypeBySyntax returns the FileType for a given syntax identifier
+// (e.g. "python", "objective-c"), or nil if no such syntax is registered.
+func GetFileTypeBySyntax(syntax string) *FileType {
+	return defaultRegistry.LookupSyntax(syntax)
+}
+
 // GetFileTypeByExtension returns the FileType for a given extension (without dot)
 func GetFileTypeByExtension(ext string) *FileType {
-	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
-	if ft, ok := extensionToFileType[ext]; ok {
-		return ft
-	}
-	return &unknownType
+	return defaultRegistry.LookupExtension(ext)
 }
 
-// GetFileTypeByMIME returns the FileType for a given MIME type
+// GetFileTypeByMIME returns the FileType for a given MIME type, checking
+// both its primary MIME and any additional MIMEs it declares.
 func GetFileTypeByMIME(mimeType string) *FileType {
-	lowerMime := strings.ToLower(mimeType)
-
-	// Try exact match first
-	if ft, ok := mimeToFileType[lowerMime]; ok {
-		return ft
-	}
-
-	// Try prefix match (for MIME types with parameters like "text/html; charset=utf-8")
-	for mime, ft := range mimeToFileType {
-		if strings.HasPrefix(lowerMime, mime) {
-			return ft
-		}
-	}
-
-	// Special cases for generic matches
-	if strings.Contains(lowerMime, "pdf") {
-		return GetFileTypeByExtension("pdf")
-	}
-	if strings.Contains(lowerMime, "image") {
-		return GetFileTypeByExtension("jpg")
-	}
-	if strings.Contains(lowerMime, "zip") || strings.Contains(lowerMime, "archive") || strings.Contains(lowerMime, "tar") || strings.Contains(lowerMime, "gzip") {
-		return GetFileTypeByExtension("zip")
-	}
-	if strings.Contains(lowerMime, "text") {
-		return GetFileTypeByExtension("txt")
-	}
+	return defaultRegistry.LookupMIME(mimeType)
+}
 
-	return &unknownType
+// GetFileTypeByAlias returns the FileType that declares name as one of its
+// Aliases (e.g. "c++" for cpp, "cs" for csharp), or nil if none does.
+func GetFileTypeByAlias(name string) *FileType {
+	return defaultRegistry.LookupAlias(name)
 }
 
-// DetectContentType detects the MIME type from filename and content
+// DetectContentType detects the MIME type from filename and content. Strong
+// magic numbers take priority over the extension, so a ".txt" file that is
+// actually a PNG is reported as an image. Weak, short magic numbers (like
+// "BM"/"MZ") occur incidentally in ordinary text, so they only get a say
+// once the extension is also unrecognized, as a last-resort tiebreaker.
 func DetectContentType(filename string, content []byte) string {
+	if ft := SniffMagic(content); ft != nil {
+		return ft.MIME
+	}
+
 	// Try extension-based detection first using our comprehensive mapping
 	if filename != "" {
 		ext := strings.ToLower(filepath.Ext(filename))
 		ext = strings.TrimPrefix(ext, ".")
-		if ft, ok := extensionToFileType[ext]; ok {
+		if ft := defaultRegistry.LookupExtension(ext); ft != &unknownType {
 			return ft.MIME
 		}
 
@@ -302,6 +295,11 @@ func DetectContentType(filename string, content []byte) string {
 		}
 	}
 
+	// Extension gave no signal either; let a weak magic number break the tie.
+	if ft := SniffMagicWeak(content); ft != nil {
+		return ft.MIME
+	}
+
 	// Fall back to content sniffing
 	if len(content) > 0 {
 		return http.DetectContentType(content)
@@ -311,26 +309,41 @@ func DetectContentType(filename string, content []byte) string {
 	return "text/plain"
 }
 
-// DetectLanguageHint detects the language/syntax hint from filename
-func DetectLanguageHint(filename string) string {
+// DetectLanguageHint detects the language/syntax hint from filename. By
+// default it returns the FileType's canonical Syntax identifier; passing
+// preferAlias=true returns its first registered alias instead (e.g. "c++"
+// rather than "cpp"), falling back to Syntax when the FileType has none.
+func DetectLanguageHint(filename string, preferAlias bool) string {
 	if filename == "" {
 		return ""
 	}
 
 	ext := strings.ToLower(filepath.Ext(filename))
 	ext = strings.TrimPrefix(ext, ".")
-	if ft, ok := extensionToFileType[ext]; ok {
-		return ft.Syntax
+	ft := defaultRegistry.LookupExtension(ext)
+	if ft == &unknownType {
+		return ""
 	}
 
-	return ""
+	if preferAlias && len(ft.Aliases) > 0 {
+		return ft.Aliases[0]
+	}
+	return ft.Syntax
 }
 
-// IsBinaryType checks if the content type is a binary/non-editable type
-func IsBinaryType(contentType string) bool {
+// IsBinaryType checks if the content type is a binary/non-editable type. If
+// contentType is the generic "application/octet-stream" and content is
+// non-empty, it consults the magic-number sniffer to resolve the real type
+// before deciding.
+func IsBinaryType(contentType string, content []byte) bool {
 	ft := GetFileTypeByMIME(contentType)
+	if contentType == "application/octet-stream" && len(content) > 0 {
+		if sniffed := SniffMagic(content); sniffed != nil {
+			ft = sniffed
+		}
+	}
 	switch ft.Category {
-	case "image", "pdf", "archive", "unknown":
+	case "image", "pdf", "archive", "binary", "unknown":
 		return true
 	}
 	return false
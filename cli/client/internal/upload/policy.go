@@ -0,0 +1,156 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// UploadPolicy controls whether and how a file of a given type may be
+// uploaded.
+type UploadPolicy struct {
+	MaxBytes     int64    // maximum allowed upload size in bytes; 0 means no limit
+	RequireScan  bool     // file must pass virus scanning before being accepted
+	Denied       bool     // reject uploads of this type outright
+	AllowedRoles []string // if non-empty, only these roles may upload this type
+}
+
+// SetPolicy registers an UploadPolicy override for the FileType with the
+// given syntax identifier, taking precedence over any category policy or
+// the registry's default.
+func (r *Registry) SetPolicy(syntax string, policy UploadPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.policyBySyntax[syntax] = policy
+}
+
+// SetCategoryPolicy registers an UploadPolicy override applied to every
+// FileType in category that has no more specific per-syntax override.
+func (r *Registry) SetCategoryPolicy(category string, policy UploadPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.policyByCategory[category] = policy
+}
+
+// SetDefaultPolicy replaces the fallback UploadPolicy applied to any
+// FileType without a more specific per-syntax or per-category override.
+func (r *Registry) SetDefaultPolicy(policy UploadPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.defaultPolicy = policy
+}
+
+// PolicyFor resolves the effective UploadPolicy for ft: a per-syntax
+// override if one is registered, else a per-category override, else the
+// registry's default policy. A nil ft resolves to the default policy.
+func (r *Registry) PolicyFor(ft *FileType) UploadPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if ft != nil {
+		if policy, ok := r.policyBySyntax[ft.Syntax]; ok {
+			return policy
+		}
+		if policy, ok := r.policyByCategory[ft.Category]; ok {
+			return policy
+		}
+	}
+	return r.defaultPolicy
+}
+
+// fileTypeContextKey is the context key under which WithUploadPolicy stores
+// the resolved FileType for downstream handlers.
+type fileTypeContextKey struct{}
+
+// FileTypeFromContext returns the FileType that WithUploadPolicy resolved
+// for the current request, or nil if the middleware wasn't run.
+func FileTypeFromContext(ctx context.Context) *FileType {
+	ft, _ := ctx.Value(fileTypeContextKey{}).(*FileType)
+	return ft
+}
+
+// sniffPeekBytes is how much of the request body WithUploadPolicy reads
+// up front to detect the file type, before putting it back for the next
+// handler.
+const sniffPeekBytes = 512
+
+// policyErrorResponse is the structured JSON body WithUploadPolicy writes
+// when it rejects a request.
+type policyErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// WithUploadPolicy wraps next with upload-policy enforcement: it peeks at
+// the incoming stream to resolve the file's FileType (via magic-number
+// sniffing, falling back to filename-based detection), looks up the
+// effective UploadPolicy from the default registry, and rejects the
+// request with a structured JSON error when the type is denied, the
+// declared content length exceeds the policy's limit, or the caller's role
+// isn't allowed. Otherwise it stores the resolved FileType in the request
+// context (see FileTypeFromContext) and calls next with the body
+// reconstructed so downstream handlers see the full, unconsumed stream,
+// capped at policy.MaxBytes via http.MaxBytesReader so a request that
+// omits Content-Length (or lies about it) can't bypass the limit.
+func WithUploadPolicy(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peek := make([]byte, sniffPeekBytes)
+		n, err := io.ReadFull(r.Body, peek)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			writePolicyError(w, http.StatusBadRequest, "failed to read upload")
+			return
+		}
+		peek = peek[:n]
+
+		ft := SniffMagic(peek)
+		if ft == nil {
+			ft = DetectLanguage(r.URL.Query().Get("filename"), peek)
+		}
+
+		policy := defaultRegistry.PolicyFor(ft)
+
+		if policy.Denied {
+			writePolicyError(w, http.StatusForbidden, fmt.Sprintf("uploads of type %q are not allowed", ft.Syntax))
+			return
+		}
+		if policy.MaxBytes > 0 && r.ContentLength > policy.MaxBytes {
+			writePolicyError(w, http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("file of type %q exceeds the %d byte limit", ft.Syntax, policy.MaxBytes))
+			return
+		}
+		if len(policy.AllowedRoles) > 0 && !roleAllowed(r.Header.Get("X-Upload-Role"), policy.AllowedRoles) {
+			writePolicyError(w, http.StatusForbidden, fmt.Sprintf("your role may not upload type %q", ft.Syntax))
+			return
+		}
+
+		body := io.NopCloser(io.MultiReader(bytes.NewReader(peek), r.Body))
+		if policy.MaxBytes > 0 {
+			r.Body = http.MaxBytesReader(w, body, policy.MaxBytes)
+		} else {
+			r.Body = body
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), fileTypeContextKey{}, ft)))
+	})
+}
+
+// roleAllowed reports whether role appears in allowed.
+func roleAllowed(role string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == role {
+			return true
+		}
+	}
+	return false
+}
+
+// writePolicyError writes a structured JSON error with the given status.
+func writePolicyError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(policyErrorResponse{Error: msg})
+}
@@ -0,0 +1,67 @@
+package upload
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPolicyForPrecedence(t *testing.T) {
+	r := NewRegistry()
+	r.Register(FileType{Syntax: "widget", Category: "code"})
+
+	r.SetDefaultPolicy(UploadPolicy{MaxBytes: 100})
+	r.SetCategoryPolicy("code", UploadPolicy{MaxBytes: 200})
+	r.SetPolicy("widget", UploadPolicy{MaxBytes: 300})
+
+	ft := r.LookupSyntax("widget")
+	if got := r.PolicyFor(ft); got.MaxBytes != 300 {
+		t.Errorf("PolicyFor(widget).MaxBytes = %d, want 300 (per-syntax override)", got.MaxBytes)
+	}
+
+	r.Unregister("widget")
+	r.Register(FileType{Syntax: "gadget", Category: "code"})
+	if got := r.PolicyFor(r.LookupSyntax("gadget")); got.MaxBytes != 200 {
+		t.Errorf("PolicyFor(gadget).MaxBytes = %d, want 200 (category override)", got.MaxBytes)
+	}
+
+	if got := r.PolicyFor(nil); got.MaxBytes != 100 {
+		t.Errorf("PolicyFor(nil).MaxBytes = %d, want 100 (default)", got.MaxBytes)
+	}
+}
+
+func TestWithUploadPolicyEnforcesMaxBytesRegardlessOfContentLength(t *testing.T) {
+	origRegistry := defaultRegistry
+	defer func() { defaultRegistry = origRegistry }()
+
+	defaultRegistry = NewRegistry()
+	defaultRegistry.Register(FileType{Syntax: "text", Category: "text", Extensions: []string{"txt"}, MIME: "text/plain"})
+	defaultRegistry.SetDefaultPolicy(UploadPolicy{MaxBytes: 10})
+
+	var bodyLen int
+	handler := WithUploadPolicy(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		bodyLen = len(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	body := strings.Repeat("a", 10000)
+	req := httptest.NewRequest(http.MethodPost, "/upload?filename=file.txt", strings.NewReader(body))
+	req.ContentLength = -1 // simulate chunked transfer / no declared length
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+	if bodyLen > 10 {
+		t.Fatalf("handler read %d bytes past a 10-byte MaxBytes policy", bodyLen)
+	}
+}
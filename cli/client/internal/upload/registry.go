@@ -0,0 +1,312 @@
+package upload
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Registry holds a set of FileType definitions and the indices used to look
+// them up by extension, MIME type, alias, basename pattern, or syntax
+// identifier. The zero value is not usable; use NewRegistry. A Registry is
+// safe for concurrent use.
+type Registry struct {
+	mu               sync.RWMutex
+	bySyntax         map[string]*FileType
+	byExtension      map[string]*FileType
+	byMIME           map[string]*FileType
+	byAlias          map[string]*FileType
+	filenamePatterns []*FileType // entries with a non-nil FilenamePattern, in Syntax order
+
+	policyBySyntax   map[string]UploadPolicy
+	policyByCategory map[string]UploadPolicy
+	defaultPolicy    UploadPolicy
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		bySyntax:         make(map[string]*FileType),
+		byExtension:      make(map[string]*FileType),
+		byMIME:           make(map[string]*FileType),
+		byAlias:          make(map[string]*FileType),
+		policyBySyntax:   make(map[string]UploadPolicy),
+		policyByCategory: make(map[string]UploadPolicy),
+	}
+}
+
+// Register adds ft to the registry, replacing any existing entry with the
+// same Syntax.
+func (r *Registry) Register(ft FileType) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := ft
+	r.bySyntax[ft.Syntax] = &stored
+	r.reindexLocked()
+}
+
+// Unregister removes the FileType with the given syntax identifier, if any.
+func (r *Registry) Unregister(syntax string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.bySyntax, syntax)
+	r.reindexLocked()
+}
+
+// reindexLocked rebuilds the extension and MIME indices from bySyntax. It
+// must be called with mu held for writing. Rebuilding from scratch on every
+// mutation keeps Register/Unregister simple and correct when an override
+// changes which extensions or MIME types a syntax claims.
+func (r *Registry) reindexLocked() {
+	r.byExtension = make(map[string]*FileType)
+	r.byMIME = make(map[string]*FileType)
+	r.byAlias = make(map[string]*FileType)
+	r.filenamePatterns = nil
+
+	// Walk syntaxes in sorted order so that an extension or MIME type claimed
+	// by more than one FileType always resolves the same way (the later
+	// Syntax wins) and filenamePatterns has a stable order.
+	syntaxes := make([]string, 0, len(r.bySyntax))
+	for syntax := range r.bySyntax {
+		syntaxes = append(syntaxes, syntax)
+	}
+	sort.Strings(syntaxes)
+
+	for _, syntax := range syntaxes {
+		ft := r.bySyntax[syntax]
+		for _, ext := range ft.Extensions {
+			r.byExtension[strings.ToLower(ext)] = ft
+		}
+		r.byMIME[strings.ToLower(ft.MIME)] = ft
+		for _, m := range ft.MIMEs {
+			r.byMIME[strings.ToLower(m)] = ft
+		}
+		for _, alias := range ft.Aliases {
+			r.byAlias[strings.ToLower(alias)] = ft
+		}
+		if ft.FilenamePattern != nil {
+			r.filenamePatterns = append(r.filenamePatterns, ft)
+		}
+	}
+}
+
+// LookupSyntax returns the FileType registered under syntax, or nil if none
+// is registered.
+func (r *Registry) LookupSyntax(syntax string) *FileType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if ft, ok := r.bySyntax[syntax]; ok {
+		return ft
+	}
+	return nil
+}
+
+// LookupExtension returns the FileType for a given extension (with or
+// without a leading dot), or the unknown FileType if none matches.
+func (r *Registry) LookupExtension(ext string) *FileType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.lookupExtensionLocked(ext)
+}
+
+func (r *Registry) lookupExtensionLocked(ext string) *FileType {
+	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+	if ft, ok := r.byExtension[ext]; ok {
+		return ft
+	}
+	return &unknownType
+}
+
+// LookupMIME returns the FileType for a given MIME type, or the unknown
+// FileType if none matches. It tries an exact match, then a prefix match
+// (for MIME types carrying parameters like "text/html; charset=utf-8"),
+// then falls back to a handful of generic substring matches.
+func (r *Registry) LookupMIME(mimeType string) *FileType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lowerMime := strings.ToLower(mimeType)
+
+	if ft, ok := r.byMIME[lowerMime]; ok {
+		return ft
+	}
+
+	for registered, ft := range r.byMIME {
+		if strings.HasPrefix(lowerMime, registered) {
+			return ft
+		}
+	}
+
+	switch {
+	case strings.Contains(lowerMime, "pdf"):
+		return r.lookupExtensionLocked("pdf")
+	case strings.Contains(lowerMime, "image"):
+		return r.lookupExtensionLocked("jpg")
+	case strings.Contains(lowerMime, "zip"), strings.Contains(lowerMime, "archive"),
+		strings.Contains(lowerMime, "tar"), strings.Contains(lowerMime, "gzip"):
+		return r.lookupExtensionLocked("zip")
+	case strings.Contains(lowerMime, "text"):
+		return r.lookupExtensionLocked("txt")
+	}
+
+	return &unknownType
+}
+
+// LookupAlias returns the FileType that declares name as one of its
+// Aliases, or nil if none does.
+func (r *Registry) LookupAlias(name string) *FileType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if ft, ok := r.byAlias[strings.ToLower(name)]; ok {
+		return ft
+	}
+	return nil
+}
+
+// LookupFilename returns the FileType whose FilenamePattern matches the
+// basename of filename (e.g. "CMakeLists.txt", ".htaccess"), or the unknown
+// FileType if none matches. Patterns are tried in order of the FileTypes'
+// Syntax identifiers; the first match wins.
+func (r *Registry) LookupFilename(filename string) *FileType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	base := filepath.Base(filename)
+	for _, ft := range r.filenamePatterns {
+		if ft.FilenamePattern.MatchString(base) {
+			return ft
+		}
+	}
+	return &unknownType
+}
+
+// Categories returns the sorted, deduplicated list of categories among the
+// registered FileTypes.
+func (r *Registry) Categories() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	categories := make([]string, 0, len(r.bySyntax))
+	for _, ft := range r.bySyntax {
+		if !seen[ft.Category] {
+			seen[ft.Category] = true
+			categories = append(categories, ft.Category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// policyOverride targets an UploadPolicy at either a single syntax
+// identifier or a whole category; Syntax takes precedence when both are
+// somehow set.
+type policyOverride struct {
+	Syntax   string       `yaml:"syntax" json:"syntax"`
+	Category string       `yaml:"category" json:"category"`
+	Policy   UploadPolicy `yaml:"policy" json:"policy"`
+}
+
+// registryFile is the document shape accepted by LoadFromYAML and
+// LoadFromJSON: a top-level list of file type definitions to register,
+// plus optional per-syntax/per-category UploadPolicy overrides and a
+// default policy.
+type registryFile struct {
+	FileTypes     []FileType       `yaml:"file_types" json:"file_types"`
+	Policies      []policyOverride `yaml:"policies" json:"policies"`
+	DefaultPolicy *UploadPolicy    `yaml:"default_policy" json:"default_policy"`
+}
+
+// LoadFromYAML registers every FileType and UploadPolicy found in the YAML
+// document read from rd. Entries whose Syntax matches an existing
+// registration override it.
+func (r *Registry) LoadFromYAML(rd io.Reader) error {
+	var doc registryFile
+	if err := yaml.NewDecoder(rd).Decode(&doc); err != nil {
+		return fmt.Errorf("upload: decode registry YAML: %w", err)
+	}
+	r.applyRegistryFile(doc)
+	return nil
+}
+
+// LoadFromJSON registers every FileType and UploadPolicy found in the JSON
+// document read from rd. Entries whose Syntax matches an existing
+// registration override it.
+func (r *Registry) LoadFromJSON(rd io.Reader) error {
+	var doc registryFile
+	if err := json.NewDecoder(rd).Decode(&doc); err != nil {
+		return fmt.Errorf("upload: decode registry JSON: %w", err)
+	}
+	r.applyRegistryFile(doc)
+	return nil
+}
+
+// applyRegistryFile registers the FileTypes and UploadPolicy overrides
+// carried by doc.
+func (r *Registry) applyRegistryFile(doc registryFile) {
+	for _, ft := range doc.FileTypes {
+		r.Register(ft)
+	}
+	for _, p := range doc.Policies {
+		switch {
+		case p.Syntax != "":
+			r.SetPolicy(p.Syntax, p.Policy)
+		case p.Category != "":
+			r.SetCategoryPolicy(p.Category, p.Policy)
+		}
+	}
+	if doc.DefaultPolicy != nil {
+		r.SetDefaultPolicy(*doc.DefaultPolicy)
+	}
+}
+
+// RegistryDiff summarizes how two registries' syntax entries differ. It is
+// primarily a test helper for asserting the effect of a Register/Unregister
+// call or a Load* call.
+type RegistryDiff struct {
+	Added   []string // syntaxes present in the other registry but not this one
+	Removed []string // syntaxes present in this registry but not the other
+	Changed []string // syntaxes present in both but with a different definition
+}
+
+// Diff compares r against other and reports added, removed, and changed
+// syntax entries.
+func (r *Registry) Diff(other *Registry) RegistryDiff {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+
+	var diff RegistryDiff
+	for syntax, ft := range other.bySyntax {
+		existing, ok := r.bySyntax[syntax]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, syntax)
+		case !reflect.DeepEqual(*existing, *ft):
+			diff.Changed = append(diff.Changed, syntax)
+		}
+	}
+	for syntax := range r.bySyntax {
+		if _, ok := other.bySyntax[syntax]; !ok {
+			diff.Removed = append(diff.Removed, syntax)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
@@ -0,0 +1,107 @@
+package upload
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRegistryRegisterLookup(t *testing.T) {
+	r := NewRegistry()
+	r.Register(FileType{
+		Syntax:     "widget",
+		MIME:       "text/x-widget",
+		Extensions: []string{"wgt"},
+		Category:   "code",
+		Aliases:    []string{"wgt-lang"},
+	})
+
+	if ft := r.LookupSyntax("widget"); ft == nil || ft.Syntax != "widget" {
+		t.Fatalf("LookupSyntax(%q) = %v, want widget", "widget", ft)
+	}
+	if ft := r.LookupExtension("wgt"); ft.Syntax != "widget" {
+		t.Errorf("LookupExtension(%q) = %q, want widget", "wgt", ft.Syntax)
+	}
+	if ft := r.LookupMIME("text/x-widget"); ft.Syntax != "widget" {
+		t.Errorf("LookupMIME(%q) = %q, want widget", "text/x-widget", ft.Syntax)
+	}
+	if ft := r.LookupAlias("wgt-lang"); ft == nil || ft.Syntax != "widget" {
+		t.Errorf("LookupAlias(%q) = %v, want widget", "wgt-lang", ft)
+	}
+
+	r.Unregister("widget")
+	if ft := r.LookupSyntax("widget"); ft != nil {
+		t.Errorf("LookupSyntax(%q) after Unregister = %v, want nil", "widget", ft)
+	}
+	if ft := r.LookupExtension("wgt"); ft != &unknownType {
+		t.Errorf("LookupExtension(%q) after Unregister = %q, want unknown", "wgt", ft.Syntax)
+	}
+}
+
+func TestRegistryDiff(t *testing.T) {
+	base := NewRegistry()
+	base.Register(FileType{Syntax: "widget", MIME: "text/x-widget", Category: "code"})
+	base.Register(FileType{Syntax: "gadget", MIME: "text/x-gadget", Category: "code"})
+
+	other := NewRegistry()
+	other.Register(FileType{Syntax: "widget", MIME: "text/x-widget", Category: "code"})
+	other.Register(FileType{Syntax: "gizmo", MIME: "text/x-gizmo", Category: "code"})
+
+	diff := base.Diff(other)
+	if len(diff.Added) != 1 || diff.Added[0] != "gizmo" {
+		t.Errorf("Added = %v, want [gizmo]", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != "gadget" {
+		t.Errorf("Removed = %v, want [gadget]", diff.Removed)
+	}
+	if len(diff.Changed) != 0 {
+		t.Errorf("Changed = %v, want none", diff.Changed)
+	}
+
+	other.Register(FileType{Syntax: "widget", MIME: "text/x-widget-v2", Category: "code"})
+	diff = base.Diff(other)
+	if len(diff.Changed) != 1 || diff.Changed[0] != "widget" {
+		t.Errorf("Changed = %v, want [widget]", diff.Changed)
+	}
+}
+
+func TestRegistryLookupFilename(t *testing.T) {
+	r := NewRegistry()
+	r.Register(FileType{
+		Syntax:          "procfile",
+		Category:        "code",
+		FilenamePattern: regexp.MustCompile(`(?i)^Procfile$`),
+	})
+
+	if ft := r.LookupFilename("Procfile"); ft.Syntax != "procfile" {
+		t.Errorf("LookupFilename(%q) = %q, want procfile", "Procfile", ft.Syntax)
+	}
+	if ft := r.LookupFilename("/app/Procfile"); ft.Syntax != "procfile" {
+		t.Errorf("LookupFilename(%q) = %q, want procfile", "/app/Procfile", ft.Syntax)
+	}
+	if ft := r.LookupFilename("not-a-procfile"); ft != &unknownType {
+		t.Errorf("LookupFilename(%q) = %q, want unknown", "not-a-procfile", ft.Syntax)
+	}
+
+	r.Unregister("procfile")
+	if ft := r.LookupFilename("Procfile"); ft != &unknownType {
+		t.Errorf("LookupFilename(%q) after Unregister = %q, want unknown", "Procfile", ft.Syntax)
+	}
+}
+
+func TestRegistryCategories(t *testing.T) {
+	r := NewRegistry()
+	r.Register(FileType{Syntax: "widget", Category: "code"})
+	r.Register(FileType{Syntax: "gadget", Category: "code"})
+	r.Register(FileType{Syntax: "photo", Category: "image"})
+
+	got := r.Categories()
+	want := []string{"code", "image"}
+	if len(got) != len(want) {
+		t.Fatalf("Categories() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Categories()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}